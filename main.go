@@ -0,0 +1,35 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/gelembjuk/oursql/node/consensus"
+)
+
+func main() {
+	configPath := flag.String("config", "consensus_config.json", "path to the consensus config file")
+	configLax := flag.Bool("config-lax", false, "tolerate unknown keys in the consensus config file instead of rejecting them")
+	flag.Parse()
+
+	loadConfig := consensus.NewConfigFromFile
+	if *configLax {
+		loadConfig = consensus.NewConfigFromFileLax
+	}
+
+	config, err := loadConfig(*configPath)
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	runNode(config)
+}
+
+// runNode is a placeholder for the real node startup sequence (p2p,
+// mempool, miner, admin API), which lives outside this series.
+func runNode(config *consensus.ConsensusConfig) {
+	_ = config
+}
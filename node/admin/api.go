@@ -0,0 +1,240 @@
+// Package admin exposes a running node's consensus config over HTTP, so
+// ops teams can tweak TransactionCost, TableRules and InitNodesAddreses on
+// a live node instead of SSH+edit+restart.
+package admin
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gelembjuk/oursql/node/consensus"
+)
+
+const xsrfTokenTTL = 5 * time.Minute
+
+// API serves the admin HTTP endpoints for one node's consensus config.
+type API struct {
+	manager      *consensus.ConfigManager
+	secret       string
+	chainIsEmpty func() bool
+
+	tokensLock sync.Mutex
+	tokens     map[string]time.Time
+}
+
+// NewAPI builds an API over manager. secret authenticates writes, either
+// ConsensusConfig.AdminSecret or a shared secret configured on the node.
+// chainIsEmpty is stored on the API but not consulted by it: a PUT is
+// validated by manager.Replace, which uses the manager's own chainIsEmpty.
+func NewAPI(manager *consensus.ConfigManager, secret string, chainIsEmpty func() bool) *API {
+	return &API{
+		manager:      manager,
+		secret:       secret,
+		chainIsEmpty: chainIsEmpty,
+		tokens:       map[string]time.Time{},
+	}
+}
+
+// RegisterRoutes wires the admin endpoints onto mux.
+func (a *API) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/admin/config", a.handleConfig)
+	mux.HandleFunc("/admin/config/schema", a.handleSchema)
+	mux.HandleFunc("/admin/config/token", a.handleToken)
+}
+
+func (a *API) handleConfig(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		a.writeJSON(w, a.manager.Current())
+	case http.MethodPut:
+		a.handlePut(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (a *API) handleSchema(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	a.writeJSON(w, Schema())
+}
+
+// handleToken issues a one-time XSRF token that must be echoed back in the
+// X-XSRF-Token header of a PUT /admin/config request.
+func (a *API) handleToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	a.writeJSON(w, map[string]string{"token": a.issueToken()})
+}
+
+func (a *API) issueToken() string {
+	buf := make([]byte, 32)
+	rand.Read(buf)
+	token := hex.EncodeToString(buf)
+
+	a.tokensLock.Lock()
+	a.sweepExpiredTokensLocked()
+	a.tokens[token] = time.Now().Add(xsrfTokenTTL)
+	a.tokensLock.Unlock()
+
+	return token
+}
+
+// sweepExpiredTokensLocked drops tokens nobody ever came back to consume.
+// Without this, an unauthenticated caller hitting GET /admin/config/token
+// in a loop would grow a.tokens without bound; consumeToken alone only
+// ever removes a token that is actually submitted on a PUT. Caller must
+// hold tokensLock.
+func (a *API) sweepExpiredTokensLocked() {
+	now := time.Now()
+
+	for token, expiry := range a.tokens {
+		if now.After(expiry) {
+			delete(a.tokens, token)
+		}
+	}
+}
+
+func (a *API) consumeToken(token string) bool {
+	a.tokensLock.Lock()
+	defer a.tokensLock.Unlock()
+
+	expiry, ok := a.tokens[token]
+	delete(a.tokens, token)
+
+	return ok && time.Now().Before(expiry)
+}
+
+func (a *API) handlePut(w http.ResponseWriter, r *http.Request) {
+	if !a.consumeToken(r.Header.Get("X-XSRF-Token")) {
+		http.Error(w, "missing or expired XSRF token", http.StatusForbidden)
+		return
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+
+	if err != nil {
+		http.Error(w, "could not read body", http.StatusBadRequest)
+		return
+	}
+
+	if !a.verifySignature(body, r.Header.Get("X-Signature")) {
+		http.Error(w, "invalid signature", http.StatusForbidden)
+		return
+	}
+
+	next, err := consensus.DecodeConfigJSON(body, false)
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := rejectReadOnlyChanges(a.manager.Current(), next); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	// Replace validates and swaps the config in synchronously, so the
+	// response actually reflects whether the change took effect instead of
+	// handing back a 202 and hoping the file watcher's debounced reload
+	// agrees later.
+	if err := a.manager.Replace(next, body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// verifySignature checks an HMAC-SHA256 of body, keyed by the node's
+// AdminSecret or a shared secret, sent in the X-Signature header.
+func (a *API) verifySignature(body []byte, signature string) bool {
+	mac := hmac.New(sha256.New, []byte(a.secret))
+	mac.Write(body)
+	expected := hex.EncodeToString(mac.Sum(nil))
+
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+// rejectReadOnlyChanges rejects a PUT that touches any field the schema
+// marks confreadonly:"true" (Application.Name, AdminSecret, ...), driven
+// off Schema() itself so a new confreadonly tag is enforced here for free
+// instead of needing its own hand-written check. Kind is confreadonly too,
+// but changing it pre-genesis is still allowed and enforced by Validate,
+// so it is exempted here. AdminSecret is exempted for a different reason:
+// it is json:"-", so GET never returns it and a client round-tripping
+// GET->edit->PUT always submits a body with no AdminSecret key, decoding
+// to "" - comparing that against the real configured secret would reject
+// every legitimate PUT. ConfigManager.Replace re-injects the real secret
+// before anything is written, and refuses a body that sets one when none
+// is configured, so this endpoint can never actually change it either way.
+func rejectReadOnlyChanges(current, next *consensus.ConsensusConfig) error {
+	for _, field := range Schema() {
+		if !field.ReadOnly || field.Path == "Kind" || field.Path == "AdminSecret" {
+			continue
+		}
+
+		curVal, ok := valueAtPath(reflect.ValueOf(*current), field.Path)
+		if !ok {
+			continue
+		}
+
+		nextVal, ok := valueAtPath(reflect.ValueOf(*next), field.Path)
+		if !ok {
+			continue
+		}
+
+		if !reflect.DeepEqual(curVal, nextVal) {
+			return fmt.Errorf("%s is read-only", field.Path)
+		}
+	}
+
+	return nil
+}
+
+// valueAtPath follows a dotted FieldSchema.Path (e.g. "Application.Name")
+// through nested structs, dereferencing pointers along the way. ok is
+// false if any segment of the path doesn't resolve, e.g. a nil pointer.
+func valueAtPath(v reflect.Value, path string) (interface{}, bool) {
+	for _, part := range strings.Split(path, ".") {
+		for v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return nil, false
+			}
+			v = v.Elem()
+		}
+
+		if v.Kind() != reflect.Struct {
+			return nil, false
+		}
+
+		v = v.FieldByName(part)
+		if !v.IsValid() {
+			return nil, false
+		}
+	}
+
+	return v.Interface(), true
+}
+
+func (a *API) writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,68 @@
+package admin
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gelembjuk/oursql/node/consensus"
+)
+
+func sign(t *testing.T, secret string, body []byte) string {
+	t.Helper()
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestHandlePutSucceedsWhenAdminSecretIsConfigured(t *testing.T) {
+	dir := t.TempDir()
+	filePath := dir + "/config.json"
+
+	seed := []byte(`{"Application":{"Name":"testapp"},"Kind":"proofofwork"}`)
+	if err := ioutil.WriteFile(filePath, seed, 0644); err != nil {
+		t.Fatalf("failed to seed config file: %v", err)
+	}
+
+	current := &consensus.ConsensusConfig{}
+	current.Application.Name = "testapp"
+	current.Kind = consensus.KindConseususPoW
+	current.AdminSecret = "supersecret"
+	current.SetConfigFilePath(filePath)
+
+	manager, err := consensus.NewConfigManager(current, func() bool { return true })
+	if err != nil {
+		t.Fatalf("failed to create config manager: %v", err)
+	}
+	defer manager.Close()
+
+	api := NewAPI(manager, "supersecret", func() bool { return true })
+
+	// a GET never returns AdminSecret, so the body an honest client PUTs
+	// back never carries it either
+	putBody := seed
+	signature := sign(t, "supersecret", putBody)
+	token := api.issueToken()
+
+	req := httptest.NewRequest(http.MethodPut, "/admin/config", bytes.NewReader(putBody))
+	req.Header.Set("X-XSRF-Token", token)
+	req.Header.Set("X-Signature", signature)
+
+	rec := httptest.NewRecorder()
+	api.handlePut(rec, req)
+
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("expected 202 Accepted, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	if manager.Current().AdminSecret != "supersecret" {
+		t.Fatalf("expected AdminSecret to survive the PUT, got %q", manager.Current().AdminSecret)
+	}
+}
@@ -0,0 +1,67 @@
+package admin
+
+import (
+	"reflect"
+
+	"github.com/gelembjuk/oursql/node/consensus"
+)
+
+// FieldSchema describes one field of ConsensusConfig for an admin UI: its
+// dotted path, a human description, whether it can be changed on a live
+// node, and, for enum-like fields, the values it accepts.
+type FieldSchema struct {
+	Path          string
+	Title         string
+	Description   string
+	ReadOnly      bool
+	AllowedValues []string `json:",omitempty"`
+}
+
+// Schema walks ConsensusConfig, ConsensusConfigTable and ConsensusConfigCost
+// and returns one FieldSchema per exported field, built from their
+// confdesc/confreadonly struct tags.
+func Schema() []FieldSchema {
+	return schemaFor(reflect.TypeOf(consensus.ConsensusConfig{}), "")
+}
+
+func schemaFor(t reflect.Type, prefix string) []FieldSchema {
+	var fields []FieldSchema
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		if f.PkgPath != "" {
+			// unexported, e.g. consensusConfigState
+			continue
+		}
+
+		path := f.Name
+		if prefix != "" {
+			path = prefix + "." + f.Name
+		}
+
+		fs := FieldSchema{
+			Path:        path,
+			Title:       f.Name,
+			Description: f.Tag.Get("confdesc"),
+			ReadOnly:    f.Tag.Get("confreadonly") == "true",
+		}
+
+		if path == "Kind" {
+			fs.AllowedValues = consensus.RegisteredKinds()
+		}
+
+		fields = append(fields, fs)
+
+		elemType := f.Type
+		for elemType.Kind() == reflect.Slice || elemType.Kind() == reflect.Ptr {
+			elemType = elemType.Elem()
+		}
+
+		if elemType.Kind() == reflect.Struct {
+			fields = append(fields, schemaFor(elemType, path)...)
+		}
+	}
+
+	return fields
+}
@@ -0,0 +1,324 @@
+package consensus
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/gelembjuk/oursql/node/structures"
+)
+
+const (
+	KindConsensusBFT = "bft"
+
+	voteTypePreVote   = "prevote"
+	voteTypePreCommit = "precommit"
+)
+
+// Validator is one member of the fixed validator set carried in
+// BFTSettings. VotingPower drives both proposer rotation and the
+// >2/3 threshold used to commit a block.
+type Validator struct {
+	Address     string
+	PubKey      string
+	VotingPower int64
+}
+
+// BFTSettings is the Settings for KindConsensusBFT: a Tendermint-style
+// round based engine with a fixed validator set.
+type BFTSettings struct {
+	Validators []Validator
+}
+
+func (s *BFTSettings) completeSettings() {
+	// no optional fields yet, the validator set must always be explicit
+}
+
+func (s BFTSettings) totalVotingPower() int64 {
+	total := int64(0)
+	for _, v := range s.Validators {
+		total += v.VotingPower
+	}
+	return total
+}
+
+// proposerAt returns the validator that must propose at height/round,
+// chosen deterministically by weighted round robin so every node agrees
+// without talking to each other first.
+func (s BFTSettings) proposerAt(height uint64, round int) (Validator, error) {
+	if len(s.Validators) == 0 {
+		return Validator{}, errors.New("bft: validator set is empty")
+	}
+
+	total := s.totalVotingPower()
+	if total == 0 {
+		return Validator{}, errors.New("bft: total voting power is zero")
+	}
+
+	// weight the (height, round) seed by cumulative voting power so
+	// validators with more power come up proportionally more often
+	seed := int64(height) + int64(round)
+	offset := seed % total
+
+	var acc int64
+	for _, v := range s.Validators {
+		acc += v.VotingPower
+		if offset < acc {
+			return v, nil
+		}
+	}
+
+	return s.Validators[len(s.Validators)-1], nil
+}
+
+// voteSignBytes is the canonical message a validator signs to cast v. It
+// intentionally excludes Signature itself.
+func voteSignBytes(v structures.Vote) []byte {
+	return []byte(fmt.Sprintf("%d|%d|%s|%s", v.Height, v.Round, v.Type, v.BlockHash))
+}
+
+// verifyVoteSignature checks v.Signature against pubKeyHex for v's content.
+// Both PubKey and Signature travel hex encoded, the same as addresses do
+// elsewhere in this codebase.
+func verifyVoteSignature(v structures.Vote, pubKeyHex string) bool {
+	pubKey, err := hex.DecodeString(pubKeyHex)
+	if err != nil || len(pubKey) != ed25519.PublicKeySize {
+		return false
+	}
+
+	sig, err := hex.DecodeString(v.Signature)
+	if err != nil {
+		return false
+	}
+
+	return ed25519.Verify(ed25519.PublicKey(pubKey), voteSignBytes(v), sig)
+}
+
+// roundState tracks votes seen for one (height, round) so equivocation
+// (two different votes from the same validator at the same H/R) can be
+// detected and rejected.
+type roundState struct {
+	preVotes   map[string]structures.Vote
+	preCommits map[string]structures.Vote
+}
+
+func newRoundState() *roundState {
+	return &roundState{
+		preVotes:   map[string]structures.Vote{},
+		preCommits: map[string]structures.Vote{},
+	}
+}
+
+// addVote records a vote, rejecting it as equivocation if the validator
+// already voted differently for this height/round/type. The caller must
+// have already verified v's signature: addVote only tracks the votes it
+// is handed, it doesn't re-check who sent them.
+func (rs *roundState) addVote(v structures.Vote) error {
+	bucket := rs.preVotes
+	if v.Type == voteTypePreCommit {
+		bucket = rs.preCommits
+	}
+
+	if existing, ok := bucket[v.Validator]; ok && existing.BlockHash != v.BlockHash {
+		return fmt.Errorf("bft: equivocation by validator %s at height %d round %d", v.Validator, v.Height, v.Round)
+	}
+
+	bucket[v.Validator] = v
+
+	return nil
+}
+
+// preCommitPowerFor sums the voting power of validators that pre-committed
+// blockHash, used to check the >2/3 commit threshold.
+func (rs *roundState) preCommitPowerFor(blockHash string, validators []Validator) int64 {
+	power := map[string]int64{}
+	for _, v := range validators {
+		power[v.Address] = v.VotingPower
+	}
+
+	var total int64
+	for addr, vote := range rs.preCommits {
+		if vote.BlockHash == blockHash {
+			total += power[addr]
+		}
+	}
+
+	return total
+}
+
+// roundKey identifies one (height, round) pair. Votes are only compared
+// for equivocation within the same key: a validator legitimately pre-votes
+// for a different block in round R+1 once round R fails to reach quorum,
+// and that must not be flagged as a double vote.
+type roundKey struct {
+	height uint64
+	round  int
+}
+
+// bftEngine is the round based BFT engine: a block commits once more
+// than two thirds of the total voting power pre-commits the same hash
+// at the same height and round.
+type bftEngine struct {
+	settings BFTSettings
+	rounds   map[roundKey]*roundState
+}
+
+func newBFTEngine(settings interface{}) Engine {
+	e := &bftEngine{rounds: map[roundKey]*roundState{}}
+
+	if s, ok := settings.(BFTSettings); ok {
+		e.settings = s
+	} else if s, ok := settings.(*BFTSettings); ok && s != nil {
+		e.settings = *s
+	}
+
+	e.settings.completeSettings()
+
+	return e
+}
+
+func (e *bftEngine) Name() string {
+	return KindConsensusBFT
+}
+
+func (e *bftEngine) SettingsType() interface{} {
+	return &BFTSettings{}
+}
+
+func (e *bftEngine) roundStateFor(height uint64, round int) *roundState {
+	key := roundKey{height: height, round: round}
+
+	rs, ok := e.rounds[key]
+	if !ok {
+		rs = newRoundState()
+		e.rounds[key] = rs
+	}
+	return rs
+}
+
+// validatorByAddress looks up a validator by address in the configured set.
+func (s BFTSettings) validatorByAddress(address string) (Validator, bool) {
+	for _, v := range s.Validators {
+		if v.Address == address {
+			return v, true
+		}
+	}
+	return Validator{}, false
+}
+
+// RegisterVote feeds one pre-vote/pre-commit received over the p2p layer
+// into the round state for its height and round. It rejects votes from
+// unknown validators, votes with a bad signature, and equivocating votes
+// (same validator, same height+round, different block hash).
+func (e *bftEngine) RegisterVote(v structures.Vote) error {
+	validator, ok := e.settings.validatorByAddress(v.Validator)
+	if !ok {
+		return fmt.Errorf("bft: vote from unknown validator %s", v.Validator)
+	}
+
+	if !verifyVoteSignature(v, validator.PubKey) {
+		return fmt.Errorf("bft: invalid signature on vote from validator %s", v.Validator)
+	}
+
+	return e.roundStateFor(v.Height, v.Round).addVote(v)
+}
+
+// TryCommit returns the LastCommit for blockHash at height/round once
+// pre-commits for it cross two thirds of the total voting power, or nil
+// if the threshold has not been reached yet.
+func (e *bftEngine) TryCommit(height uint64, round int, blockHash string) *structures.LastCommit {
+	rs := e.roundStateFor(height, round)
+
+	power := rs.preCommitPowerFor(blockHash, e.settings.Validators)
+	total := e.settings.totalVotingPower()
+
+	if total == 0 || power*3 <= total*2 {
+		return nil
+	}
+
+	commits := make([]structures.Vote, 0, len(rs.preCommits))
+	for _, v := range rs.preCommits {
+		if v.BlockHash == blockHash {
+			commits = append(commits, v)
+		}
+	}
+
+	return &structures.LastCommit{
+		Height:     height,
+		Round:      round,
+		BlockHash:  blockHash,
+		PreCommits: commits,
+	}
+}
+
+func (e *bftEngine) ValidateBlock(block *structures.Block) error {
+	if block.Height == 0 {
+		// genesis has no prior round that could have committed it, there
+		// is nothing to verify; ProposeBlock never sets LastCommit on it
+		return nil
+	}
+
+	if block.LastCommit == nil {
+		return errors.New("bft: block is missing LastCommit")
+	}
+
+	power := int64(0)
+	seen := map[string]bool{}
+
+	for _, vote := range block.LastCommit.PreCommits {
+		if vote.BlockHash != block.LastCommit.BlockHash {
+			return fmt.Errorf("bft: pre-commit for wrong block hash in LastCommit")
+		}
+
+		if seen[vote.Validator] {
+			return fmt.Errorf("bft: duplicate pre-commit from validator %s in LastCommit", vote.Validator)
+		}
+		seen[vote.Validator] = true
+
+		validator, ok := e.settings.validatorByAddress(vote.Validator)
+		if !ok {
+			return fmt.Errorf("bft: LastCommit has a pre-commit from unknown validator %s", vote.Validator)
+		}
+
+		if !verifyVoteSignature(vote, validator.PubKey) {
+			return fmt.Errorf("bft: LastCommit has an invalid signature from validator %s", vote.Validator)
+		}
+
+		power += validator.VotingPower
+	}
+
+	total := e.settings.totalVotingPower()
+	if total == 0 || power*3 <= total*2 {
+		return errors.New("bft: LastCommit does not reach two thirds of voting power")
+	}
+
+	return nil
+}
+
+func (e *bftEngine) ProposeBlock(prevBlock *structures.Block, txs []structures.Transaction) (*structures.Block, error) {
+	height := uint64(0)
+	if prevBlock != nil {
+		height = prevBlock.Height + 1
+	}
+
+	proposer, err := e.settings.proposerAt(height, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return structures.NewBlock(prevBlock, txs, proposer.Address)
+}
+
+func (e *bftEngine) FinalizeBlock(block *structures.Block) error {
+	for key := range e.rounds {
+		if key.height == block.Height {
+			delete(e.rounds, key)
+		}
+	}
+	return nil
+}
+
+func init() {
+	Register(KindConsensusBFT, newBFTEngine)
+}
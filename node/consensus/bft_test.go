@@ -0,0 +1,187 @@
+package consensus
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"testing"
+
+	"github.com/gelembjuk/oursql/node/structures"
+)
+
+type bftTestValidator struct {
+	validator Validator
+	private   ed25519.PrivateKey
+}
+
+func newBFTTestValidator(t *testing.T, address string, power int64) bftTestValidator {
+	t.Helper()
+
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	return bftTestValidator{
+		validator: Validator{Address: address, PubKey: hex.EncodeToString(pub), VotingPower: power},
+		private:   priv,
+	}
+}
+
+func (v bftTestValidator) vote(height uint64, round int, voteType, blockHash string) structures.Vote {
+	vote := structures.Vote{
+		Height:    height,
+		Round:     round,
+		Type:      voteType,
+		BlockHash: blockHash,
+		Validator: v.validator.Address,
+	}
+
+	vote.Signature = hex.EncodeToString(ed25519.Sign(v.private, voteSignBytes(vote)))
+
+	return vote
+}
+
+func newTestBFTEngine(t *testing.T, validators ...bftTestValidator) *bftEngine {
+	t.Helper()
+
+	settings := BFTSettings{}
+	for _, v := range validators {
+		settings.Validators = append(settings.Validators, v.validator)
+	}
+
+	return newBFTEngine(settings).(*bftEngine)
+}
+
+func TestBFTCommitsOnceTwoThirdsPreCommit(t *testing.T) {
+	a := newBFTTestValidator(t, "addrA", 1)
+	b := newBFTTestValidator(t, "addrB", 1)
+	c := newBFTTestValidator(t, "addrC", 1)
+
+	engine := newTestBFTEngine(t, a, b, c)
+
+	if err := engine.RegisterVote(a.vote(1, 0, voteTypePreCommit, "hash1")); err != nil {
+		t.Fatalf("unexpected error registering vote: %v", err)
+	}
+
+	if commit := engine.TryCommit(1, 0, "hash1"); commit != nil {
+		t.Fatalf("expected no commit yet with 1/3 voting power, got %+v", commit)
+	}
+
+	if err := engine.RegisterVote(b.vote(1, 0, voteTypePreCommit, "hash1")); err != nil {
+		t.Fatalf("unexpected error registering vote: %v", err)
+	}
+
+	// exactly 2/3 of the voting power is not enough, the rule is >2/3
+	if commit := engine.TryCommit(1, 0, "hash1"); commit != nil {
+		t.Fatalf("expected no commit at exactly 2/3 voting power, got %+v", commit)
+	}
+
+	if err := engine.RegisterVote(c.vote(1, 0, voteTypePreCommit, "hash1")); err != nil {
+		t.Fatalf("unexpected error registering vote: %v", err)
+	}
+
+	commit := engine.TryCommit(1, 0, "hash1")
+	if commit == nil {
+		t.Fatal("expected commit once more than 2/3 voting power pre-committed")
+	}
+
+	if len(commit.PreCommits) != 3 {
+		t.Fatalf("expected 3 pre-commits in LastCommit, got %d", len(commit.PreCommits))
+	}
+}
+
+func TestBFTRejectsEquivocationWithinSameRound(t *testing.T) {
+	a := newBFTTestValidator(t, "addrA", 1)
+	engine := newTestBFTEngine(t, a)
+
+	if err := engine.RegisterVote(a.vote(1, 0, voteTypePreVote, "hash1")); err != nil {
+		t.Fatalf("unexpected error on first vote: %v", err)
+	}
+
+	if err := engine.RegisterVote(a.vote(1, 0, voteTypePreVote, "hash2")); err == nil {
+		t.Fatal("expected a second, different vote in the same height+round to be rejected as equivocation")
+	}
+}
+
+func TestBFTAllowsDifferentVoteInLaterRound(t *testing.T) {
+	a := newBFTTestValidator(t, "addrA", 1)
+	engine := newTestBFTEngine(t, a)
+
+	if err := engine.RegisterVote(a.vote(1, 0, voteTypePreVote, "hash1")); err != nil {
+		t.Fatalf("unexpected error voting in round 0: %v", err)
+	}
+
+	// round 0 failed to reach quorum, the validator moves on to round 1 and
+	// pre-votes a different block: this is normal round-advance, not a
+	// double vote, and must be accepted.
+	if err := engine.RegisterVote(a.vote(1, 1, voteTypePreVote, "hash2")); err != nil {
+		t.Fatalf("expected a vote for a different block in a later round to be accepted, got %v", err)
+	}
+}
+
+func TestBFTRejectsVoteWithBadSignature(t *testing.T) {
+	a := newBFTTestValidator(t, "addrA", 1)
+	engine := newTestBFTEngine(t, a)
+
+	vote := a.vote(1, 0, voteTypePreCommit, "hash1")
+	vote.Signature = hex.EncodeToString(make([]byte, ed25519.SignatureSize))
+
+	if err := engine.RegisterVote(vote); err == nil {
+		t.Fatal("expected a vote with an invalid signature to be rejected")
+	}
+}
+
+func TestValidateBlockRejectsForgedLastCommit(t *testing.T) {
+	a := newBFTTestValidator(t, "addrA", 1)
+
+	engine := newTestBFTEngine(t, a)
+
+	forged := structures.Vote{
+		Height:    1,
+		Round:     0,
+		Type:      voteTypePreCommit,
+		BlockHash: "hash1",
+		Validator: a.validator.Address,
+		Signature: hex.EncodeToString(make([]byte, ed25519.SignatureSize)),
+	}
+
+	block := &structures.Block{
+		Height: 1,
+		Hash:   "hash1",
+		LastCommit: &structures.LastCommit{
+			Height:     1,
+			Round:      0,
+			BlockHash:  "hash1",
+			PreCommits: []structures.Vote{forged},
+		},
+	}
+
+	if err := engine.ValidateBlock(block); err == nil {
+		t.Fatal("expected a LastCommit with a forged signature to be rejected")
+	}
+}
+
+func TestBFTValidateBlockAcceptsGenesisWithoutLastCommit(t *testing.T) {
+	a := newBFTTestValidator(t, "addrA", 1)
+	engine := newTestBFTEngine(t, a)
+
+	genesis, err := engine.ProposeBlock(nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error proposing genesis: %v", err)
+	}
+
+	if err := engine.ValidateBlock(genesis); err != nil {
+		t.Fatalf("expected genesis to validate without a LastCommit, got %v", err)
+	}
+}
+
+func TestBFTValidateBlockRejectsMissingLastCommitPastGenesis(t *testing.T) {
+	a := newBFTTestValidator(t, "addrA", 1)
+	engine := newTestBFTEngine(t, a)
+
+	block := &structures.Block{Height: 1, Hash: "hash1"}
+
+	if err := engine.ValidateBlock(block); err == nil {
+		t.Fatal("expected a non-genesis block without a LastCommit to be rejected")
+	}
+}
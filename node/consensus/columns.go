@@ -0,0 +1,112 @@
+package consensus
+
+import "fmt"
+
+// Note on wiring: GetTableRule/GetColumnRule, ResolveColumnUpdateCost,
+// CheckColumnUpdateAllowed and CheckColumnReadAllowed are the rule
+// resolution for column-level table rules. The SQL parser/dispatch path
+// that would call these for each column in a SET/WHERE/SELECT clause
+// lives outside this trimmed series (this tree has no node/sql package to
+// wire them into) - these are the resolution primitives a dispatcher
+// would call, not yet a working column-gating feature end to end.
+
+// GetTableRule returns the table rule configured for table, if any.
+func (cc ConsensusConfig) GetTableRule(table string) (ConsensusConfigTable, bool) {
+	for _, t := range cc.TableRules {
+		if t.Table == table {
+			return t, true
+		}
+	}
+
+	return ConsensusConfigTable{}, false
+}
+
+// GetColumnRule returns the column rule configured for column inside this
+// table rule, if any.
+func (t ConsensusConfigTable) GetColumnRule(column string) (ConsensusConfigColumn, bool) {
+	for _, c := range t.Columns {
+		if c.Column == column {
+			return c, true
+		}
+	}
+
+	return ConsensusConfigColumn{}, false
+}
+
+// ResolveColumnUpdateCost returns the cost of an UPDATE that touches
+// column of table, resolving column override -> table override ->
+// global default, in that order. A level is only skipped when its
+// RowUpdate override is nil (not set), so an explicit cost of 0 is
+// honored rather than falling through to a more expensive level.
+func (cc ConsensusConfig) ResolveColumnUpdateCost(table, column string) float64 {
+	if t, ok := cc.GetTableRule(table); ok {
+		if c, ok := t.GetColumnRule(column); ok && c.TransactionCost.RowUpdate != nil {
+			return *c.TransactionCost.RowUpdate
+		}
+
+		if t.TransactionCost.RowUpdate != nil {
+			return *t.TransactionCost.RowUpdate
+		}
+	}
+
+	if cc.TransactionCost.RowUpdate != nil {
+		return *cc.TransactionCost.RowUpdate
+	}
+
+	if cc.TransactionCost.Default != nil {
+		return *cc.TransactionCost.Default
+	}
+
+	return 0
+}
+
+// CheckColumnUpdateAllowed is meant to be consulted by the SQL dispatcher
+// for every column named in a SET clause (see the package-level note: that
+// dispatcher doesn't exist in this tree yet). rowValues holds the current
+// values of the row being updated, needed to check RequireSignerColumn.
+func (cc ConsensusConfig) CheckColumnUpdateAllowed(table, column, signerAddress string, rowValues map[string]string) error {
+	t, ok := cc.GetTableRule(table)
+	if !ok {
+		return nil
+	}
+
+	c, ok := t.GetColumnRule(column)
+	if !ok {
+		return nil
+	}
+
+	if !c.AllowUpdate {
+		return fmt.Errorf("update of column %s.%s is not allowed", table, column)
+	}
+
+	if c.RequireSignerColumn != "" {
+		owner, exists := rowValues[c.RequireSignerColumn]
+
+		if !exists || owner != signerAddress {
+			return fmt.Errorf("only the holder of %s.%s may update column %s.%s", table, c.RequireSignerColumn, table, column)
+		}
+	}
+
+	return nil
+}
+
+// CheckColumnReadAllowed is meant to be consulted by the SQL dispatcher
+// for every column named in a SELECT or WHERE clause (see the
+// package-level note: that dispatcher doesn't exist in this tree yet).
+func (cc ConsensusConfig) CheckColumnReadAllowed(table, column string) error {
+	t, ok := cc.GetTableRule(table)
+	if !ok {
+		return nil
+	}
+
+	c, ok := t.GetColumnRule(column)
+	if !ok {
+		return nil
+	}
+
+	if !c.AllowRead {
+		return fmt.Errorf("reading column %s.%s is not allowed", table, column)
+	}
+
+	return nil
+}
@@ -0,0 +1,91 @@
+package consensus
+
+import "testing"
+
+func floatPtr(f float64) *float64 {
+	return &f
+}
+
+func TestResolveColumnUpdateCostPrecedence(t *testing.T) {
+	cc := ConsensusConfig{
+		TransactionCost: ConsensusConfigCost{Default: floatPtr(1), RowUpdate: floatPtr(2)},
+		TableRules: []ConsensusConfigTable{
+			{
+				Table:           "accounts",
+				TransactionCost: ConsensusConfigCost{RowUpdate: floatPtr(3)},
+				Columns: []ConsensusConfigColumn{
+					{Column: "balance", TransactionCost: ConsensusConfigCost{RowUpdate: floatPtr(0.5)}},
+					{Column: "nickname"},
+				},
+			},
+		},
+	}
+
+	if got := cc.ResolveColumnUpdateCost("accounts", "balance"); got != 0.5 {
+		t.Fatalf("expected column override 0.5, got %v", got)
+	}
+
+	if got := cc.ResolveColumnUpdateCost("accounts", "nickname"); got != 3 {
+		t.Fatalf("expected table override 3, got %v", got)
+	}
+
+	if got := cc.ResolveColumnUpdateCost("other_table", "whatever"); got != 2 {
+		t.Fatalf("expected global RowUpdate 2, got %v", got)
+	}
+}
+
+func TestResolveColumnUpdateCostHonorsExplicitZero(t *testing.T) {
+	cc := ConsensusConfig{
+		TransactionCost: ConsensusConfigCost{Default: floatPtr(1), RowUpdate: floatPtr(2)},
+		TableRules: []ConsensusConfigTable{
+			{
+				Table: "accounts",
+				Columns: []ConsensusConfigColumn{
+					{Column: "free_column", TransactionCost: ConsensusConfigCost{RowUpdate: floatPtr(0)}},
+				},
+			},
+		},
+	}
+
+	if got := cc.ResolveColumnUpdateCost("accounts", "free_column"); got != 0 {
+		t.Fatalf("expected explicit zero cost to be honored, got %v", got)
+	}
+}
+
+func TestCheckColumnUpdateAllowedRequiresSigner(t *testing.T) {
+	cc := ConsensusConfig{
+		TableRules: []ConsensusConfigTable{
+			{
+				Table: "accounts",
+				Columns: []ConsensusConfigColumn{
+					{Column: "balance", AllowUpdate: true, RequireSignerColumn: "owner"},
+				},
+			},
+		},
+	}
+
+	rowValues := map[string]string{"owner": "addr1"}
+
+	if err := cc.CheckColumnUpdateAllowed("accounts", "balance", "addr1", rowValues); err != nil {
+		t.Fatalf("expected owner update to be allowed, got %v", err)
+	}
+
+	if err := cc.CheckColumnUpdateAllowed("accounts", "balance", "addr2", rowValues); err == nil {
+		t.Fatal("expected non-owner update to be rejected")
+	}
+}
+
+func TestCheckColumnUpdateAllowedRejectsDisallowedColumn(t *testing.T) {
+	cc := ConsensusConfig{
+		TableRules: []ConsensusConfigTable{
+			{
+				Table:   "accounts",
+				Columns: []ConsensusConfigColumn{{Column: "balance", AllowUpdate: false}},
+			},
+		},
+	}
+
+	if err := cc.CheckColumnUpdateAllowed("accounts", "balance", "addr1", nil); err == nil {
+		t.Fatal("expected update of a disallowed column to be rejected")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"io/ioutil"
 	"math/rand"
+	"reflect"
 	"strings"
 	"time"
 
@@ -18,48 +19,94 @@ const (
 	KindConseususPoW = "proofofwork"
 )
 
+// ConsensusConfigCost holds per-operation costs. Fields are pointers so a
+// table/column override can explicitly set a cost of 0 (free) and have it
+// honored, instead of an unset zero value being indistinguishable from an
+// explicit zero and falling through to the next, more expensive level.
 type ConsensusConfigCost struct {
-	Default     float64
-	RowDelete   float64
-	RowUpdate   float64
-	RowInsert   float64
-	TableCreate float64
+	Default     *float64 `confdesc:"Cost charged when no more specific override applies"`
+	RowDelete   *float64 `confdesc:"Cost of a row DELETE"`
+	RowUpdate   *float64 `confdesc:"Cost of a row UPDATE"`
+	RowInsert   *float64 `confdesc:"Cost of a row INSERT"`
+	TableCreate *float64 `confdesc:"Cost of a CREATE TABLE"`
 }
 type ConsensusConfigTable struct {
-	Table            string
-	AllowRowDelete   bool
-	AllowRowUpdate   bool
-	AllowRowInsert   bool
-	AllowTableCreate bool
-	TransactionCost  ConsensusConfigCost
+	Table            string                  `confdesc:"Table this rule applies to"`
+	AllowRowDelete   bool                    `confdesc:"Whether DELETE is allowed on this table"`
+	AllowRowUpdate   bool                    `confdesc:"Whether UPDATE is allowed on this table"`
+	AllowRowInsert   bool                    `confdesc:"Whether INSERT is allowed on this table"`
+	AllowTableCreate bool                    `confdesc:"Whether this table may be (re)created"`
+	TransactionCost  ConsensusConfigCost     `confdesc:"Cost overrides for this table"`
+	Columns          []ConsensusConfigColumn `confdesc:"Per-column overrides for this table"`
+}
+
+// ConsensusConfigColumn narrows a table rule down to one column, so e.g.
+// anyone can INSERT a row but only the address in RequireSignerColumn can
+// UPDATE a given column, and that column can carry its own cost.
+type ConsensusConfigColumn struct {
+	Column              string              `confdesc:"Column this rule applies to"`
+	AllowUpdate         bool                `confdesc:"Whether this column may be UPDATEd"`
+	AllowRead           bool                `confdesc:"Whether this column may be read"`
+	RequireSignerColumn string              `confdesc:"Column whose value must equal the tx sender's address to UPDATE"`
+	TransactionCost     ConsensusConfigCost `confdesc:"Cost overrides for this column"`
 }
 type ConsensusConfigApplication struct {
-	Name    string
-	WebSite string
-	Team    string
+	Name    string `confdesc:"Application name shown to peers" confreadonly:"true"`
+	WebSite string `confdesc:"Application website"`
+	Team    string `confdesc:"Application team/maintainers"`
 }
 type consensusConfigState struct {
 	isDefault bool
 	filePath  string
 }
 type ConsensusConfig struct {
-	Application       ConsensusConfigApplication
-	Kind              string
-	CoinsForBlockMade float64
-	Settings          map[string]interface{}
-	AllowTableCreate  bool
-	AllowTableDrop    bool
-	AllowRowDelete    bool
-	TransactionCost   ConsensusConfigCost
-	UnmanagedTables   []string
-	TableRules        []ConsensusConfigTable
-	InitNodesAddreses []string
+	Application       ConsensusConfigApplication `confdesc:"Application identity"`
+	Kind              string                     `confdesc:"Consensus engine kind (proofofwork, bft, ...)" confreadonly:"true"`
+	CoinsForBlockMade float64                    `confdesc:"Reward paid to whoever makes a block"`
+	Settings          map[string]interface{}     `confdesc:"Engine-specific settings, shape depends on Kind"`
+	AllowTableCreate  bool                       `confdesc:"Whether CREATE TABLE is allowed globally"`
+	AllowTableDrop    bool                       `confdesc:"Whether DROP TABLE is allowed globally"`
+	AllowRowDelete    bool                       `confdesc:"Whether row DELETE is allowed globally"`
+	TransactionCost   ConsensusConfigCost        `confdesc:"Default per-operation transaction costs"`
+	UnmanagedTables   []string                   `confdesc:"Tables the consensus layer does not govern"`
+	TableRules        []ConsensusConfigTable     `confdesc:"Per-table and per-column rule overrides"`
+	InitNodesAddreses []string                   `confdesc:"Bootstrap peer addresses handed to new nodes"`
+	// AdminSecret is deliberately excluded from JSON marshaling (json:"-")
+	// so it never leaks out of GET /admin/config or any other place that
+	// serializes a ConsensusConfig. newConfigFromFile/Export read and
+	// write it explicitly instead.
+	AdminSecret string `json:"-" confdesc:"Shared secret used to authenticate admin API writes" confreadonly:"true"`
 	state             consensusConfigState
 }
 
 // Load config from config file. Some config options an be missed
-// missed options must be replaced with default values correctly
+// missed options must be replaced with default values correctly.
+// Unknown top-level keys (typos like AllowRowDeete) and unknown keys inside
+// Settings are rejected; keys inside other nested objects (Application,
+// TransactionCost, TableRules and their Columns) are not checked and are
+// ignored when unknown. Use NewConfigFromFileLax (the --config-lax CLI
+// flag) for the old behaviour.
 func NewConfigFromFile(filepath string) (*ConsensusConfig, error) {
+	return newConfigFromFile(filepath, false)
+}
+
+// NewConfigFromFileLax loads a config the same way NewConfigFromFile does,
+// but silently ignores unknown keys instead of rejecting them. It backs
+// the legacy --config-lax CLI flag for configs nobody wants to fix yet.
+func NewConfigFromFileLax(filepath string) (*ConsensusConfig, error) {
+	return newConfigFromFile(filepath, true)
+}
+
+// DecodeConfigJSON decodes a consensus config from raw JSON bytes the same
+// way NewConfigFromFile does, for callers that already have the bytes in
+// hand (e.g. the admin API's PUT body) rather than a file path. The
+// returned config has no file path set; callers that need one should call
+// SetConfigFilePath themselves.
+func DecodeConfigJSON(jsonStr []byte, lax bool) (*ConsensusConfig, error) {
+	return decodeConfigJSON(jsonStr, lax)
+}
+
+func newConfigFromFile(filepath string, lax bool) (*ConsensusConfig, error) {
 	// we open a file only if it exists. in other case options can be set with command line
 
 	jsonStr, err := ioutil.ReadFile(filepath)
@@ -69,14 +116,51 @@ func NewConfigFromFile(filepath string) (*ConsensusConfig, error) {
 		return nil, err
 	}
 
+	config, err := decodeConfigJSON(jsonStr, lax)
+
+	if err != nil {
+		return nil, err
+	}
+
+	config.state.filePath = filepath
+
+	return config, nil
+}
+
+func decodeConfigJSON(jsonStr []byte, lax bool) (*ConsensusConfig, error) {
 	config := ConsensusConfig{}
 
-	err = json.Unmarshal(jsonStr, &config)
+	var err error
+
+	if lax {
+		err = json.Unmarshal(jsonStr, &config)
+	} else {
+		// enumerate every unknown top-level key before decoding, so a file
+		// with several typos is reported in one pass instead of one per
+		// restart (json.Decoder.DisallowUnknownFields stops at the first)
+		fieldErrs, unknownErr := unknownTopLevelKeys(jsonStr, reflect.TypeOf(config))
+
+		if unknownErr != nil {
+			return nil, unknownErr
+		}
+
+		if len(fieldErrs) > 0 {
+			return nil, &ConfigParseError{Errors: fieldErrs}
+		}
+
+		err = json.Unmarshal(jsonStr, &config)
+	}
 
 	if err != nil {
 		return nil, err
 	}
 
+	// AdminSecret is json:"-" so the decode above never touches it, read
+	// it out of the raw file explicitly instead.
+	if secret, ok := extractTopLevelString(jsonStr, "AdminSecret"); ok {
+		config.AdminSecret = secret
+	}
+
 	if config.CoinsForBlockMade == 0 {
 		config.CoinsForBlockMade = 10
 	}
@@ -84,19 +168,37 @@ func NewConfigFromFile(filepath string) (*ConsensusConfig, error) {
 	if config.Kind == "" {
 		config.Kind = KindConseususPoW
 	}
-	if config.Kind == KindConseususPoW {
-		// check all PoW settings are done
-		s := ProofOfWorkSettings{}
 
-		mapstructure.Decode(config.Settings, &s)
+	settings, err := SettingsTypeFor(config.Kind)
 
-		s.completeSettings()
+	if err != nil {
+		return nil, err
+	}
+
+	if lax {
+		mapstructure.Decode(config.Settings, settings)
+	} else {
+		decoder, decErr := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+			ErrorUnused: true,
+			Result:      settings,
+		})
+
+		if decErr != nil {
+			return nil, decErr
+		}
+
+		if decErr := decoder.Decode(config.Settings); decErr != nil {
+			return nil, newSettingsParseError(decErr)
+		}
+	}
 
-		config.Settings = structs.Map(s)
+	if s, ok := settings.(completableSettings); ok {
+		s.completeSettings()
 	}
 
+	config.Settings = structs.Map(settings)
+
 	config.state.isDefault = false
-	config.state.filePath = filepath
 
 	return &config, nil
 }
@@ -187,6 +289,17 @@ func (cc ConsensusConfig) Export(defaultaddresses string, appname string, thisno
 
 	jsondata, err = json.Marshal(cc)
 
+	if err != nil {
+		return
+	}
+
+	// AdminSecret is json:"-" so it survived the marshal above only if we
+	// put it back: re-inject it so a node's own export of its config keeps
+	// the secret it was configured with.
+	if cc.AdminSecret != "" {
+		jsondata, err = reinjectTopLevelString(jsondata, "AdminSecret", cc.AdminSecret)
+	}
+
 	return
 }
 
@@ -215,6 +328,12 @@ func (cc *ConsensusConfig) SetConfigFilePath(fp string) {
 	cc.state.filePath = fp
 }
 
+// ConfigFilePath returns the path this config was loaded from / should be
+// saved to, or "" for a default config with nothing on disk yet.
+func (cc ConsensusConfig) ConfigFilePath() string {
+	return cc.state.filePath
+}
+
 // Replace consensus config file . It checks if a config is correct, if can be parsed
 
 func (cc ConsensusConfig) UpdateConfig(jsondoc []byte) error {
@@ -225,3 +344,25 @@ func (cc ConsensusConfig) UpdateConfig(jsondoc []byte) error {
 
 	return ioutil.WriteFile(cc.state.filePath, jsondoc, 0644)
 }
+
+// Validate checks that Application.Name is set and that Kind names a
+// registered engine, plus checks that need to know about the config being
+// replaced: previous is the config currently in effect (nil when there is
+// none yet), and chainIsEmpty tells whether the node has mined/received
+// any blocks yet. It does not repeat the unknown-key and Settings checks
+// that decoding (NewConfigFromFile, DecodeConfigJSON) applies.
+func (cc ConsensusConfig) Validate(previous *ConsensusConfig, chainIsEmpty bool) error {
+	if cc.Application.Name == "" {
+		return errors.New("Application name is empty. It is required")
+	}
+
+	if _, err := SettingsTypeFor(cc.Kind); err != nil {
+		return err
+	}
+
+	if previous != nil && cc.Kind != previous.Kind && !chainIsEmpty {
+		return errors.New("consensus Kind can not be changed once the chain has blocks")
+	}
+
+	return nil
+}
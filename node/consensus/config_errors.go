@@ -0,0 +1,178 @@
+package consensus
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ConfigFieldError is one offending key found while strictly decoding a
+// consensus config file.
+type ConfigFieldError struct {
+	Key     string
+	Line    int // 0 when the line could not be determined
+	Message string
+}
+
+// ConfigParseError is returned by NewConfigFromFile when the config file
+// has one or more unknown keys, so operators can fix every typo in one
+// pass instead of one-per-restart.
+type ConfigParseError struct {
+	Errors []ConfigFieldError
+}
+
+func (e *ConfigParseError) Error() string {
+	parts := make([]string, len(e.Errors))
+
+	for i, fe := range e.Errors {
+		if fe.Line > 0 {
+			parts[i] = fmt.Sprintf("%s (line %d): %s", fe.Key, fe.Line, fe.Message)
+		} else {
+			parts[i] = fmt.Sprintf("%s: %s", fe.Key, fe.Message)
+		}
+	}
+
+	return fmt.Sprintf("consensus config has %d problem(s):\n%s", len(e.Errors), strings.Join(parts, "\n"))
+}
+
+// unknownTopLevelKeys enumerates every key in jsonStr that does not match
+// an exported field of t. Unlike json.Decoder.DisallowUnknownFields,
+// which aborts at the first offending key, this walks every key in the
+// object so a file with several typos is reported in one pass.
+func unknownTopLevelKeys(jsonStr []byte, t reflect.Type) ([]ConfigFieldError, error) {
+	raw := map[string]json.RawMessage{}
+
+	if err := json.Unmarshal(jsonStr, &raw); err != nil {
+		return nil, err
+	}
+
+	valid := map[string]bool{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		if f.PkgPath != "" {
+			// unexported, e.g. consensusConfigState
+			continue
+		}
+
+		valid[strings.ToLower(f.Name)] = true
+	}
+
+	var errs []ConfigFieldError
+
+	for key := range raw {
+		if valid[strings.ToLower(key)] {
+			continue
+		}
+
+		errs = append(errs, ConfigFieldError{
+			Key:     key,
+			Line:    lineForKey(jsonStr, key),
+			Message: "unknown field, check for typos",
+		})
+	}
+
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Key < errs[j].Key })
+
+	return errs, nil
+}
+
+// extractTopLevelString reads one top-level string field directly out of
+// jsonStr, bypassing struct tags. Used for fields like AdminSecret that
+// are tagged json:"-" so they never get marshaled back out.
+func extractTopLevelString(jsonStr []byte, key string) (string, bool) {
+	raw := map[string]json.RawMessage{}
+
+	if err := json.Unmarshal(jsonStr, &raw); err != nil {
+		return "", false
+	}
+
+	fieldRaw, ok := raw[key]
+	if !ok {
+		return "", false
+	}
+
+	var value string
+	if err := json.Unmarshal(fieldRaw, &value); err != nil {
+		return "", false
+	}
+
+	return value, true
+}
+
+// reinjectTopLevelString adds key/value as a top-level field into an
+// already marshaled JSON object. Used to put AdminSecret back into a
+// config's exported JSON after json:"-" dropped it from the marshal.
+func reinjectTopLevelString(jsonData []byte, key, value string) ([]byte, error) {
+	raw := map[string]json.RawMessage{}
+
+	if err := json.Unmarshal(jsonData, &raw); err != nil {
+		return nil, err
+	}
+
+	valueJSON, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	raw[key] = valueJSON
+
+	return json.Marshal(raw)
+}
+
+// lineForKey finds the 1-based line a top-level "key" first appears on,
+// or 0 if it can't be found.
+func lineForKey(data []byte, key string) int {
+	idx := bytes.Index(data, []byte(`"`+key+`"`))
+
+	if idx < 0 {
+		return 0
+	}
+
+	return lineForOffset(data, int64(idx))
+}
+
+// newSettingsParseError wraps a mapstructure ErrorUnused error, which lists
+// every unused key in one message, into one ConfigFieldError per key.
+func newSettingsParseError(decErr error) error {
+	msg := decErr.Error()
+
+	const marker = "invalid keys: "
+	idx := strings.Index(msg, marker)
+
+	if idx < 0 {
+		return &ConfigParseError{Errors: []ConfigFieldError{{Key: "Settings", Message: msg}}}
+	}
+
+	rest := strings.TrimRight(msg[idx+len(marker):], "\n ")
+
+	var fieldErrors []ConfigFieldError
+
+	for _, key := range strings.Split(rest, ", ") {
+		key = strings.TrimSpace(key)
+
+		if key == "" {
+			continue
+		}
+
+		fieldErrors = append(fieldErrors, ConfigFieldError{
+			Key:     "Settings." + key,
+			Message: "unknown field, check for typos",
+		})
+	}
+
+	return &ConfigParseError{Errors: fieldErrors}
+}
+
+// lineForOffset turns a byte offset into data into a 1-based line number,
+// or 0 if it can't be determined.
+func lineForOffset(data []byte, offset int64) int {
+	if offset <= 0 || int(offset) > len(data) {
+		return 0
+	}
+
+	return bytes.Count(data[:offset], []byte("\n")) + 1
+}
@@ -0,0 +1,201 @@
+package consensus
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+const configReloadDebounce = 200 * time.Millisecond
+
+// ConfigManager owns the live ConsensusConfig of a running node. It watches
+// the config file on disk and swaps the config in atomically whenever the
+// file is edited, so consumers never have to restart the node to pick up
+// a rule change.
+type ConfigManager struct {
+	current      atomic.Value // *ConsensusConfig
+	filePath     string
+	chainIsEmpty func() bool
+
+	watcher *fsnotify.Watcher
+
+	subsLock    sync.Mutex
+	subscribers []chan *ConsensusConfig
+}
+
+// NewConfigManager wraps initial and, if it was loaded from a file, starts
+// watching that file for changes. chainIsEmpty is asked at reload time to
+// decide whether a Kind change is still allowed.
+func NewConfigManager(initial *ConsensusConfig, chainIsEmpty func() bool) (*ConfigManager, error) {
+	m := &ConfigManager{
+		filePath:     initial.state.filePath,
+		chainIsEmpty: chainIsEmpty,
+	}
+	m.current.Store(initial)
+
+	if m.filePath == "" {
+		// default config with nothing on disk to watch
+		return m, nil
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := watcher.Add(m.filePath); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+
+	m.watcher = watcher
+
+	go m.watchLoop()
+
+	return m, nil
+}
+
+// Current returns the config currently in effect. Consumers should call
+// this each time they need the config instead of holding on to a pointer,
+// so they always see the latest rules.
+func (m *ConfigManager) Current() *ConsensusConfig {
+	return m.current.Load().(*ConsensusConfig)
+}
+
+// Subscribe returns a channel that receives the new config every time it
+// is swapped in; a subscriber that has not read the previous one yet gets
+// only the latest. The mempool, miner and SQL dispatcher use this to
+// rebuild their rule caches.
+func (m *ConfigManager) Subscribe() <-chan *ConsensusConfig {
+	ch := make(chan *ConsensusConfig, 1)
+
+	m.subsLock.Lock()
+	m.subscribers = append(m.subscribers, ch)
+	m.subsLock.Unlock()
+
+	return ch
+}
+
+// Close stops the file watcher. Safe to call on a manager with no watcher.
+func (m *ConfigManager) Close() error {
+	if m.watcher == nil {
+		return nil
+	}
+
+	return m.watcher.Close()
+}
+
+func (m *ConfigManager) watchLoop() {
+	var debounceTimer *time.Timer
+
+	for {
+		select {
+		case event, ok := <-m.watcher.Events:
+			if !ok {
+				return
+			}
+
+			if event.Op&(fsnotify.Write|fsnotify.Rename|fsnotify.Create) == 0 {
+				continue
+			}
+
+			// editors often write-truncate-write, coalesce the burst
+			if debounceTimer != nil {
+				debounceTimer.Stop()
+			}
+			debounceTimer = time.AfterFunc(configReloadDebounce, m.reload)
+
+		case _, ok := <-m.watcher.Errors:
+			if !ok {
+				return
+			}
+		}
+	}
+}
+
+func (m *ConfigManager) reload() {
+	next, err := NewConfigFromFile(m.filePath)
+
+	if err != nil {
+		// keep running on the last good config, next edit may fix the file
+		log.Printf("consensus: config reload from %s failed, keeping previous config: %v", m.filePath, err)
+		return
+	}
+
+	if err := next.Validate(m.Current(), m.chainIsEmpty()); err != nil {
+		log.Printf("consensus: config reload from %s rejected, keeping previous config: %v", m.filePath, err)
+		return
+	}
+
+	m.current.Store(next)
+	m.notify(next)
+}
+
+// Replace validates next against the config currently in effect and, if
+// it passes, writes jsondoc to disk and swaps next in immediately. It is
+// used by callers like the admin API that need to know synchronously
+// whether a change took effect, instead of writing the file and hoping
+// the file watcher's debounced reload agrees with them later.
+func (m *ConfigManager) Replace(next *ConsensusConfig, jsondoc []byte) error {
+	current := m.Current()
+
+	if err := next.Validate(current, m.chainIsEmpty()); err != nil {
+		return err
+	}
+
+	next.SetConfigFilePath(current.ConfigFilePath())
+
+	// jsondoc is typically built from whatever GET returned, which never
+	// carries AdminSecret (json:"-"), so writing it to disk verbatim would
+	// erase the secret on the first successful PUT and strand the node
+	// without a way to authenticate future writes after a restart.
+	// Re-inject the secret actually in effect, the same way Export does.
+	if current.AdminSecret != "" {
+		reinjected, err := reinjectTopLevelString(jsondoc, "AdminSecret", current.AdminSecret)
+		if err != nil {
+			return err
+		}
+
+		jsondoc = reinjected
+		next.AdminSecret = current.AdminSecret
+	} else if next.AdminSecret != "" {
+		// no secret in effect to pin to, so setting one here is refused
+		return errors.New("AdminSecret is read-only")
+	}
+
+	if err := next.UpdateConfig(jsondoc); err != nil {
+		return err
+	}
+
+	m.current.Store(next)
+	m.notify(next)
+
+	return nil
+}
+
+func (m *ConfigManager) notify(cfg *ConsensusConfig) {
+	m.subsLock.Lock()
+	defer m.subsLock.Unlock()
+
+	for _, ch := range m.subscribers {
+		select {
+		case ch <- cfg:
+		default:
+			// subscriber is behind, drop the stale update rather than block
+			// and queue this one in its place
+			select {
+			case <-ch:
+			default:
+			}
+			select {
+			case ch <- cfg:
+			default:
+			}
+		}
+	}
+}
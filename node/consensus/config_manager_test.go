@@ -0,0 +1,99 @@
+package consensus
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+)
+
+func TestReplacePreservesAdminSecretAcrossRestart(t *testing.T) {
+	dir := t.TempDir()
+	filePath := dir + "/config.json"
+
+	seed := []byte(`{"Application":{"Name":"testapp"},"Kind":"proofofwork"}`)
+	if err := ioutil.WriteFile(filePath, seed, 0644); err != nil {
+		t.Fatalf("failed to seed config file: %v", err)
+	}
+
+	current := &ConsensusConfig{}
+	current.Application.Name = "testapp"
+	current.Kind = KindConseususPoW
+	current.AdminSecret = "supersecret"
+	current.Settings = map[string]interface{}{}
+	current.state.filePath = filePath
+
+	manager, err := NewConfigManager(current, func() bool { return true })
+	if err != nil {
+		t.Fatalf("failed to create config manager: %v", err)
+	}
+	defer manager.Close()
+
+	// a PUT body built from what GET returned never carries AdminSecret,
+	// since it is json:"-"
+	putBody := seed
+
+	next, err := DecodeConfigJSON(putBody, false)
+	if err != nil {
+		t.Fatalf("failed to decode PUT body: %v", err)
+	}
+
+	if err := manager.Replace(next, putBody); err != nil {
+		t.Fatalf("expected Replace to succeed, got %v", err)
+	}
+
+	if manager.Current().AdminSecret != "supersecret" {
+		t.Fatalf("expected AdminSecret to survive the replace, got %q", manager.Current().AdminSecret)
+	}
+
+	onDisk, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("failed to read config file: %v", err)
+	}
+
+	var persisted map[string]interface{}
+	if err := json.Unmarshal(onDisk, &persisted); err != nil {
+		t.Fatalf("failed to parse persisted config: %v", err)
+	}
+
+	if persisted["AdminSecret"] != "supersecret" {
+		t.Fatalf("expected AdminSecret to be persisted to disk after a successful PUT, got %v", persisted["AdminSecret"])
+	}
+}
+
+func TestReplacePinsAdminSecretAgainstAttemptToChangeIt(t *testing.T) {
+	dir := t.TempDir()
+	filePath := dir + "/config.json"
+
+	seed := []byte(`{"Application":{"Name":"testapp"},"Kind":"proofofwork"}`)
+	if err := ioutil.WriteFile(filePath, seed, 0644); err != nil {
+		t.Fatalf("failed to seed config file: %v", err)
+	}
+
+	current := &ConsensusConfig{}
+	current.Application.Name = "testapp"
+	current.Kind = KindConseususPoW
+	current.AdminSecret = "supersecret"
+	current.Settings = map[string]interface{}{}
+	current.state.filePath = filePath
+
+	manager, err := NewConfigManager(current, func() bool { return true })
+	if err != nil {
+		t.Fatalf("failed to create config manager: %v", err)
+	}
+	defer manager.Close()
+
+	putBody := []byte(`{"Application":{"Name":"testapp"},"Kind":"proofofwork","AdminSecret":"attacker-secret"}`)
+
+	next, err := DecodeConfigJSON(putBody, false)
+	if err != nil {
+		t.Fatalf("failed to decode PUT body: %v", err)
+	}
+
+	if err := manager.Replace(next, putBody); err != nil {
+		t.Fatalf("expected Replace to succeed (AdminSecret is pinned, not rejected), got %v", err)
+	}
+
+	if manager.Current().AdminSecret != "supersecret" {
+		t.Fatalf("expected AdminSecret to stay pinned to the configured value, got %q", manager.Current().AdminSecret)
+	}
+}
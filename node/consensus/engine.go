@@ -0,0 +1,83 @@
+package consensus
+
+import (
+	"fmt"
+
+	"github.com/gelembjuk/oursql/node/structures"
+)
+
+// Engine is implemented by every consensus algorithm the node can run.
+// A config selects one engine by Kind and decodes its Settings through
+// the type the engine returns from SettingsType.
+type Engine interface {
+	// Name returns the Kind string this engine is registered under.
+	Name() string
+	// SettingsType returns a pointer to a zero value of the engine's
+	// settings struct. NewConfigFromFile decodes ConsensusConfig.Settings
+	// into it instead of assuming ProofOfWorkSettings.
+	SettingsType() interface{}
+	// ValidateBlock checks a block against the engine's rules before it
+	// is accepted into the chain (difficulty, votes, signatures, etc).
+	ValidateBlock(block *structures.Block) error
+	// ProposeBlock builds a new candidate block to be appended to the chain.
+	ProposeBlock(prevBlock *structures.Block, txs []structures.Transaction) (*structures.Block, error)
+	// FinalizeBlock runs whatever bookkeeping the engine needs once a block
+	// is committed (reset round state, persist votes, etc).
+	FinalizeBlock(block *structures.Block) error
+}
+
+// EngineFactory builds an Engine from a decoded settings value.
+type EngineFactory func(settings interface{}) Engine
+
+// completableSettings is implemented by settings structs that fill in
+// zero-valued fields with defaults after decoding (e.g. ProofOfWorkSettings).
+type completableSettings interface {
+	completeSettings()
+}
+
+var engineRegistry = map[string]EngineFactory{}
+
+// Register makes a consensus engine available under the given Kind name.
+// It is normally called from an init() function of the engine's file,
+// the same way database/sql drivers register themselves.
+func Register(name string, factory EngineFactory) {
+	if _, exists := engineRegistry[name]; exists {
+		panic("consensus: Register called twice for engine " + name)
+	}
+	engineRegistry[name] = factory
+}
+
+// NewEngine looks up the factory registered for kind and builds an Engine
+// around settings. settings is usually the already-decoded value produced
+// by the matching SettingsType().
+func NewEngine(kind string, settings interface{}) (Engine, error) {
+	factory, ok := engineRegistry[kind]
+	if !ok {
+		return nil, fmt.Errorf("consensus: unknown engine kind %s", kind)
+	}
+
+	return factory(settings), nil
+}
+
+// RegisteredKinds lists every engine Kind currently registered, so callers
+// like the admin API schema can show Kind's allowed values.
+func RegisteredKinds() []string {
+	kinds := make([]string, 0, len(engineRegistry))
+
+	for kind := range engineRegistry {
+		kinds = append(kinds, kind)
+	}
+
+	return kinds
+}
+
+// SettingsTypeFor returns a fresh settings value for kind, used by
+// NewConfigFromFile to decode ConsensusConfig.Settings correctly.
+func SettingsTypeFor(kind string) (interface{}, error) {
+	factory, ok := engineRegistry[kind]
+	if !ok {
+		return nil, fmt.Errorf("consensus: unknown engine kind %s", kind)
+	}
+
+	return factory(nil).SettingsType(), nil
+}
@@ -0,0 +1,67 @@
+package consensus
+
+import (
+	"github.com/gelembjuk/oursql/node/structures"
+)
+
+const (
+	defaultPoWDifficulty = 16
+)
+
+// ProofOfWorkSettings are the Settings for KindConseususPoW.
+type ProofOfWorkSettings struct {
+	Difficulty int
+}
+
+// completeSettings fills missing options with defaults, the same way
+// other config sections are completed after loading from file.
+func (s *ProofOfWorkSettings) completeSettings() {
+	if s.Difficulty == 0 {
+		s.Difficulty = defaultPoWDifficulty
+	}
+}
+
+// powEngine is the original, and still default, consensus engine: miners
+// race to find a block hash below a difficulty target.
+type powEngine struct {
+	settings ProofOfWorkSettings
+}
+
+func newPoWEngine(settings interface{}) Engine {
+	e := &powEngine{}
+
+	if s, ok := settings.(ProofOfWorkSettings); ok {
+		e.settings = s
+	} else if s, ok := settings.(*ProofOfWorkSettings); ok && s != nil {
+		e.settings = *s
+	}
+
+	e.settings.completeSettings()
+
+	return e
+}
+
+func (e *powEngine) Name() string {
+	return KindConseususPoW
+}
+
+func (e *powEngine) SettingsType() interface{} {
+	return &ProofOfWorkSettings{}
+}
+
+func (e *powEngine) ValidateBlock(block *structures.Block) error {
+	return block.Hash.ValidateAgainstDifficulty(e.settings.Difficulty)
+}
+
+func (e *powEngine) ProposeBlock(prevBlock *structures.Block, txs []structures.Transaction) (*structures.Block, error) {
+	return structures.MineBlock(prevBlock, txs, e.settings.Difficulty)
+}
+
+func (e *powEngine) FinalizeBlock(block *structures.Block) error {
+	// nothing to do, the mined hash is already the proof
+	return nil
+}
+
+func init() {
+	Register(KindConseususPoW, newPoWEngine)
+}
@@ -0,0 +1,30 @@
+package consensus
+
+import (
+	"testing"
+
+	"github.com/gelembjuk/oursql/node/structures"
+)
+
+func TestPoWProposeBlockProducesAValidatableBlock(t *testing.T) {
+	engine := newPoWEngine(ProofOfWorkSettings{Difficulty: 8})
+
+	block, err := engine.ProposeBlock(nil, []structures.Transaction{{ID: "tx1"}})
+	if err != nil {
+		t.Fatalf("unexpected error proposing block: %v", err)
+	}
+
+	if err := engine.ValidateBlock(block); err != nil {
+		t.Fatalf("expected the engine's own proposed block to validate, got %v", err)
+	}
+}
+
+func TestPoWValidateBlockRejectsAnUnminedHash(t *testing.T) {
+	engine := newPoWEngine(ProofOfWorkSettings{Difficulty: 32})
+
+	block := &structures.Block{Hash: "not-a-mined-hash"}
+
+	if err := engine.ValidateBlock(block); err == nil {
+		t.Fatal("expected a hash that does not satisfy the difficulty to be rejected")
+	}
+}
@@ -0,0 +1,144 @@
+package structures
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Hash is a block hash, hex encoded.
+type Hash string
+
+// ValidateAgainstDifficulty checks that the hash has at least difficulty
+// leading zero bits, the way proof-of-work mined blocks are accepted.
+func (h Hash) ValidateAgainstDifficulty(difficulty int) error {
+	zeroNibbles := difficulty / 4
+	extraBits := difficulty % 4
+
+	ok := zeroNibbles <= len(h) && strings.Trim(string(h[:zeroNibbles]), "0") == ""
+
+	if ok && extraBits > 0 {
+		// the next hex digit must have its top extraBits bits clear
+		ok = zeroNibbles < len(h) && h[zeroNibbles] >= '0' && h[zeroNibbles] < '0'+byte(1<<(4-extraBits))
+	}
+
+	if !ok {
+		return fmt.Errorf("block hash %s does not satisfy difficulty %d", h, difficulty)
+	}
+
+	return nil
+}
+
+// Transaction is a single transaction included in a block.
+type Transaction struct {
+	ID   string
+	Data []byte
+}
+
+// Vote is a single pre-vote or pre-commit signed by a BFT validator for a
+// block hash at a given height and round.
+type Vote struct {
+	Height    uint64
+	Round     int
+	Type      string
+	BlockHash string
+	Validator string
+	Signature string
+}
+
+// LastCommit is the set of pre-commit votes that justified committing the
+// previous block. It travels with the block so a replaying node can check
+// the chain was built correctly without re-running vote collection over
+// the p2p layer.
+type LastCommit struct {
+	Height     uint64
+	Round      int
+	BlockHash  string
+	PreCommits []Vote
+}
+
+// Block is one block of the chain. Proposer and LastCommit are only
+// populated under a BFT-style consensus engine; proof-of-work blocks
+// leave them empty and rely on Hash/Nonce instead.
+type Block struct {
+	Height       uint64
+	PrevHash     Hash
+	Hash         Hash
+	Nonce        uint64
+	Timestamp    int64
+	Transactions []Transaction
+	Proposer     string
+	LastCommit   *LastCommit
+}
+
+// NewBlock builds the next block on top of prevBlock for a round based
+// engine: no mining, the block is valid once it is signed off by the
+// engine's vote collection, so Hash is just a content hash, not a proof.
+func NewBlock(prevBlock *Block, txs []Transaction, proposer string) (*Block, error) {
+	if proposer == "" {
+		return nil, errors.New("structures: block proposer is required")
+	}
+
+	block := &Block{
+		Transactions: txs,
+		Proposer:     proposer,
+		Timestamp:    time.Now().Unix(),
+	}
+
+	if prevBlock != nil {
+		block.Height = prevBlock.Height + 1
+		block.PrevHash = prevBlock.Hash
+	}
+
+	block.Hash = block.contentHash()
+
+	return block, nil
+}
+
+// maxMiningAttempts bounds the nonce search. It is generous enough that a
+// real difficulty target is found long before it is hit; it exists so a
+// pathological difficulty (e.g. one nobody can ever satisfy) fails loudly
+// instead of looping forever.
+const maxMiningAttempts = 1 << 32
+
+// MineBlock builds the next block on top of prevBlock for proof-of-work:
+// it searches increasing nonces until the content hash satisfies
+// difficulty, the same way any PoW miner does.
+func MineBlock(prevBlock *Block, txs []Transaction, difficulty int) (*Block, error) {
+	block := &Block{
+		Transactions: txs,
+		Timestamp:    time.Now().Unix(),
+	}
+
+	if prevBlock != nil {
+		block.Height = prevBlock.Height + 1
+		block.PrevHash = prevBlock.Hash
+	}
+
+	for nonce := uint64(0); nonce < maxMiningAttempts; nonce++ {
+		block.Nonce = nonce
+		block.Hash = block.contentHash()
+
+		if block.Hash.ValidateAgainstDifficulty(difficulty) == nil {
+			return block, nil
+		}
+	}
+
+	return nil, fmt.Errorf("structures: could not mine a block satisfying difficulty %d in %d attempts", difficulty, maxMiningAttempts)
+}
+
+func (b *Block) contentHash() Hash {
+	h := sha256.New()
+	h.Write([]byte(b.PrevHash))
+
+	for _, tx := range b.Transactions {
+		h.Write([]byte(tx.ID))
+	}
+
+	fmt.Fprintf(h, "|%d", b.Nonce)
+
+	return Hash(hex.EncodeToString(h.Sum(nil)))
+}
@@ -0,0 +1,53 @@
+package structures
+
+import "testing"
+
+func TestMineBlockFindsANonceSatisfyingDifficulty(t *testing.T) {
+	block, err := MineBlock(nil, []Transaction{{ID: "tx1"}}, 8)
+	if err != nil {
+		t.Fatalf("unexpected error mining block: %v", err)
+	}
+
+	if err := block.Hash.ValidateAgainstDifficulty(8); err != nil {
+		t.Fatalf("mined block hash does not satisfy its own difficulty: %v", err)
+	}
+}
+
+func TestMineBlockIsDeterministicForTheSameNonce(t *testing.T) {
+	block, err := MineBlock(nil, []Transaction{{ID: "tx1"}}, 8)
+	if err != nil {
+		t.Fatalf("unexpected error mining block: %v", err)
+	}
+
+	replay := &Block{Transactions: block.Transactions, Nonce: block.Nonce}
+
+	if replay.contentHash() != block.Hash {
+		t.Fatalf("replaying the winning nonce produced a different hash: %s vs %s", replay.contentHash(), block.Hash)
+	}
+}
+
+func TestMineBlockChainsOntoPrevBlock(t *testing.T) {
+	genesis, err := MineBlock(nil, nil, 4)
+	if err != nil {
+		t.Fatalf("unexpected error mining genesis: %v", err)
+	}
+
+	next, err := MineBlock(genesis, []Transaction{{ID: "tx1"}}, 4)
+	if err != nil {
+		t.Fatalf("unexpected error mining next block: %v", err)
+	}
+
+	if next.Height != genesis.Height+1 {
+		t.Fatalf("expected height %d, got %d", genesis.Height+1, next.Height)
+	}
+
+	if next.PrevHash != genesis.Hash {
+		t.Fatalf("expected PrevHash to be the genesis hash")
+	}
+}
+
+func TestNewBlockRequiresAProposer(t *testing.T) {
+	if _, err := NewBlock(nil, nil, ""); err == nil {
+		t.Fatal("expected an empty proposer to be rejected")
+	}
+}